@@ -0,0 +1,295 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An encrypted archive is a portable backup of the whole keychain,
+// independent of whichever import/export format (internal/importers) the
+// caller happens to use day to day. The layout is:
+//
+//	"2FA1"                          4-byte magic
+//	uint32 big-endian manifest length
+//	manifest JSON (archiveManifest)
+//	AES-256-GCM ciphertext, sealed with the manifest bytes as AAD so
+//	tampering with the KDF parameters invalidates the archive
+//
+// The plaintext sealed inside is a JSON array of archiveEntry.
+const archiveMagic = "2FA1"
+
+type archiveManifest struct {
+	Version   int          `json:"version"`
+	Cipher    string       `json:"cipher"`
+	KDF       string       `json:"kdf"`
+	KDFParams scryptParams `json:"kdfparams"`
+	Nonce     string       `json:"nonce"`
+}
+
+type archiveEntry struct {
+	Name    string `json:"name"`
+	Secret  string `json:"secret"`
+	Digits  int    `json:"digits"`
+	HOTP    bool   `json:"hotp"`
+	Counter uint64 `json:"counter,omitempty"`
+}
+
+// exportEncryptedArchive writes every key in the keychain to filename as a
+// passphrase-protected archive, prompting twice for a new passphrase.
+func (c *Keychain) exportEncryptedArchive(filename string) {
+	passphrase, err := readNewPassphrase()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	var names []string
+	for name := range c.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries []archiveEntry
+	for _, name := range names {
+		k := c.keys[name]
+		if k.backend && k.raw == nil {
+			raw, err := c.secretStore().Get(name)
+			if err != nil {
+				log.Printf("skipping %q: %v", name, err)
+				continue
+			}
+			k.raw = raw
+		}
+		e := archiveEntry{
+			Name:   name,
+			Secret: base32.StdEncoding.EncodeToString(k.raw),
+			Digits: k.digits,
+			HOTP:   k.offset != 0,
+		}
+		if e.HOTP {
+			n, err := strconv.ParseUint(string(c.data[k.offset:k.offset+counterLen]), 10, 64)
+			if err != nil {
+				log.Printf("skipping %q: malformed counter", name)
+				continue
+			}
+			e.Counter = n
+		}
+		entries = append(entries, e)
+	}
+
+	body, err := json.Marshal(entries)
+	if err != nil {
+		log.Fatalf("serializing keychain: %v", err)
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		log.Fatalf("generating salt: %v", err)
+	}
+	params := scryptParams{
+		N:     defaultScryptN,
+		R:     defaultScryptR,
+		P:     defaultScryptP,
+		DKLen: 32, // one AES-256 key; GCM authenticates, so no separate MAC key
+		Salt:  hex.EncodeToString(salt),
+	}
+	key, err := deriveKey(passphrase, params)
+	if err != nil {
+		log.Fatalf("deriving key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		log.Fatalf("generating nonce: %v", err)
+	}
+
+	manifest := archiveManifest{
+		Version:   1,
+		Cipher:    "aes-256-gcm",
+		KDF:       "scrypt",
+		KDFParams: params,
+		Nonce:     hex.EncodeToString(nonce),
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		log.Fatalf("serializing archive manifest: %v", err)
+	}
+
+	// The manifest is authenticated (but not encrypted) as GCM additional
+	// data, so a corrupted or tampered manifest fails to decrypt instead
+	// of silently being trusted.
+	ciphertext := gcm.Seal(nil, nonce, body, manifestBytes)
+
+	var out bytes.Buffer
+	out.WriteString(archiveMagic)
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(manifestBytes)))
+	out.Write(lenBuf[:])
+	out.Write(manifestBytes)
+	out.Write(ciphertext)
+
+	if err := os.WriteFile(filename, out.Bytes(), 0600); err != nil {
+		log.Fatalf("writing archive: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d key(s) to encrypted archive %s\n", len(entries), filename)
+}
+
+// importEncryptedArchive decrypts filename (prompting once for its
+// passphrase) and merges its entries into the keychain, preserving
+// ordering via rewriteSorted. Keys that already exist are kept unless
+// overwrite is set.
+func (c *Keychain) importEncryptedArchive(filename string, overwrite bool) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("reading archive: %v", err)
+	}
+	if len(raw) < len(archiveMagic)+4 || string(raw[:len(archiveMagic)]) != archiveMagic {
+		log.Fatalf("%s: not a 2fa encrypted archive", filename)
+	}
+	raw = raw[len(archiveMagic):]
+	manifestLen := binary.BigEndian.Uint32(raw[:4])
+	raw = raw[4:]
+	if uint32(len(raw)) < manifestLen {
+		log.Fatalf("%s: truncated archive", filename)
+	}
+	manifestBytes := raw[:manifestLen]
+	ciphertext := raw[manifestLen:]
+
+	var manifest archiveManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		log.Fatalf("parsing archive manifest: %v", err)
+	}
+	if manifest.Cipher != "aes-256-gcm" || manifest.KDF != "scrypt" {
+		log.Fatalf("unsupported archive cipher/kdf: %s/%s", manifest.Cipher, manifest.KDF)
+	}
+
+	passphrase, err := readPassphrase("archive passphrase: ")
+	if err != nil {
+		log.Fatalf("reading passphrase: %v", err)
+	}
+	key, err := deriveKey(passphrase, manifest.KDFParams)
+	if err != nil {
+		log.Fatalf("deriving key: %v", err)
+	}
+	nonce, err := hex.DecodeString(manifest.Nonce)
+	if err != nil {
+		log.Fatalf("malformed archive nonce: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	body, err := gcm.Open(nil, nonce, ciphertext, manifestBytes)
+	if err != nil {
+		log.Fatalf("incorrect passphrase or corrupt archive")
+	}
+
+	var entries []archiveEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		log.Fatalf("parsing archive body: %v", err)
+	}
+
+	c.mergeArchive(entries, overwrite)
+}
+
+// mergeArchive rebuilds the keychain's lines from the union of its current
+// keys and entries, letting overwrite decide which copy wins for names
+// present in both.
+func (c *Keychain) mergeArchive(entries []archiveEntry, overwrite bool) {
+	incoming := map[string]archiveEntry{}
+	var incomingNames []string
+	for _, e := range entries {
+		if e.Name == "" {
+			continue
+		}
+		incoming[e.Name] = e
+		incomingNames = append(incomingNames, e.Name)
+	}
+	sort.Strings(incomingNames)
+
+	var existingNames []string
+	for name := range c.keys {
+		existingNames = append(existingNames, name)
+	}
+	sort.Strings(existingNames)
+
+	var lines []string
+	skipped := 0
+	for _, name := range existingNames {
+		if _, dup := incoming[name]; dup && overwrite {
+			continue // dropped here, replaced by the incoming entry below
+		} else if dup {
+			skipped++
+		}
+		k := c.keys[name]
+		secretField := keyringSentinel
+		if !k.backend {
+			secretField = base32.StdEncoding.EncodeToString(k.raw)
+		}
+		line := fmt.Sprintf("%s %d %s", name, k.digits, secretField)
+		if k.offset != 0 {
+			line += " " + string(c.data[k.offset:k.offset+counterLen])
+		}
+		lines = append(lines, line)
+	}
+
+	imported := 0
+	for _, name := range incomingNames {
+		if _, exists := c.keys[name]; exists && !overwrite {
+			continue
+		}
+		e := incoming[name]
+		secret := strings.Map(noSpace, e.Secret)
+		secret += strings.Repeat("=", -len(secret)&7) // pad to 8 bytes
+		raw, err := decodeKey(secret)
+		if err != nil {
+			log.Printf("skipping %q: invalid secret key: %v", name, err)
+			continue
+		}
+		digits := e.Digits
+		if digits == 0 {
+			digits = 6
+		}
+		secretField := c.resolveSecretField(name, raw, secret)
+		line := fmt.Sprintf("%s %d %s", name, digits, secretField)
+		if e.HOTP {
+			line += " " + fmt.Sprintf("%0*d", counterLen, e.Counter)
+		}
+		lines = append(lines, line)
+		imported++
+	}
+
+	c.data = nil // discard the old plaintext so rewriteSorted uses only lines
+	if err := c.rewriteSorted(lines); err != nil {
+		log.Fatalf("rewriting keychain: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d key(s) from archive (%d skipped, already present)\n", imported, skipped)
+}
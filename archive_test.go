@@ -0,0 +1,71 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+)
+
+func TestMergeArchiveKeepsExistingOnDuplicateWithoutOverwrite(t *testing.T) {
+	c := newTestKeychain(t)
+	existingRaw := []byte{1, 2, 3, 4, 5}
+	c.keys["alice"] = Key{raw: existingRaw, digits: 6}
+
+	c.mergeArchive([]archiveEntry{
+		{Name: "alice", Secret: "JBSWY3DPEHPK3PXP", Digits: 6},
+	}, false)
+
+	lines := strings.Split(strings.TrimSpace(string(c.data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("c.data has %d lines, want 1: %q", len(lines), c.data)
+	}
+	wantSecret := base32.StdEncoding.EncodeToString(existingRaw)
+	if !strings.Contains(lines[0], wantSecret) {
+		t.Fatalf("merged line %q does not contain the existing secret %q — key was lost", lines[0], wantSecret)
+	}
+	if strings.Contains(lines[0], "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("merged line %q contains the incoming secret even though overwrite=false", lines[0])
+	}
+}
+
+func TestMergeArchiveOverwriteReplacesExisting(t *testing.T) {
+	c := newTestKeychain(t)
+	c.keys["alice"] = Key{raw: []byte{1, 2, 3, 4, 5}, digits: 6}
+
+	c.mergeArchive([]archiveEntry{
+		{Name: "alice", Secret: "JBSWY3DPEHPK3PXP", Digits: 8},
+	}, true)
+
+	lines := strings.Split(strings.TrimSpace(string(c.data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("c.data has %d lines, want 1: %q", len(lines), c.data)
+	}
+	if !strings.Contains(lines[0], "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("merged line %q does not contain the incoming secret after overwrite=true", lines[0])
+	}
+}
+
+func TestMergeArchiveNewEntryRoutesThroughKeyring(t *testing.T) {
+	*flagBackend = "keyring"
+	defer func() { *flagBackend = "" }()
+
+	c := newTestKeychain(t)
+	store := newFakeSecretStore()
+	c.store = store
+
+	c.mergeArchive([]archiveEntry{
+		{Name: "alice", Secret: "JBSWY3DPEHPK3PXP", Digits: 6},
+	}, false)
+
+	lines := strings.Split(strings.TrimSpace(string(c.data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], keyringSentinel) {
+		t.Fatalf("merged line %q does not reference the keyring sentinel", lines)
+	}
+	if _, err := store.Get("alice"); err != nil {
+		t.Fatalf("store.Get(alice): %v", err)
+	}
+}
@@ -1,101 +1,83 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
 package main
 
 import (
-	"encoding/json"
+	"encoding/base32"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
-)
-
-// TwoFASExport represents the structure of a 2FAS export file
-type TwoFASExport struct {
-	Services []TwoFASService `json:"services"`
-}
 
-// TwoFASService represents a single service in a 2FAS export
-type TwoFASService struct {
-	Name   string    `json:"name"`
-	Secret string    `json:"secret"`
-	OTP    TwoFASOTP `json:"otp"`
-}
+	"github.com/jammutkarsh/2fa/internal/importers"
+)
 
-// TwoFASOTP contains the OTP configuration for a 2FAS service
-type TwoFASOTP struct {
-	Label     string `json:"label"`
-	Account   string `json:"account"`
-	Issuer    string `json:"issuer"`
-	Digits    int    `json:"digits"`
-	Period    int    `json:"period"`
-	Algorithm string `json:"algorithm"`
-	TokenType string `json:"tokenType"`
-}
+// importFrom imports keys from an export file into the keychain. format
+// selects which internal/importers.Importer parses it ("2fas", "otpauth",
+// "otpauth-migration", "aegis", "andotp", ...).
+func (c *Keychain) importFrom(format, filename string) {
+	imp, err := importers.Lookup(format)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
-// import2fas imports keys from a 2FAS JSON export file
-func (c *Keychain) import2fas(filename string) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		log.Fatalf("reading import file: %v", err)
 	}
 
-	var export TwoFASExport
-	if err := json.Unmarshal(data, &export); err != nil {
-		log.Fatalf("parsing 2fas JSON: %v", err)
+	entries, err := imp.Import(data)
+	if err != nil {
+		log.Fatalf("parsing %s export: %v", format, err)
 	}
 
 	imported := 0
 	var newEntries []string
-
-	for _, service := range export.Services {
-		name := c.getServiceName(service)
-		if name == "" {
-			log.Printf("skipping service with no name")
+	for _, e := range entries {
+		if e.Name == "" {
+			log.Printf("skipping entry with no name")
 			continue
 		}
-
-		// Check if key already exists
-		if _, exists := c.keys[name]; exists {
-			log.Printf("skipping %q: already exists", name)
+		if _, exists := c.keys[e.Name]; exists {
+			log.Printf("skipping %q: already exists", e.Name)
 			continue
 		}
 
-		// Validate and format the secret key
-		secret := strings.Map(noSpace, service.Secret)
+		secret := strings.Map(noSpace, e.Secret)
 		secret += strings.Repeat("=", -len(secret)&7) // pad to 8 bytes
-		if _, err := decodeKey(secret); err != nil {
-			log.Printf("skipping %q: invalid secret key: %v", name, err)
+		raw, err := decodeKey(secret)
+		if err != nil {
+			log.Printf("skipping %q: invalid secret key: %v", e.Name, err)
 			continue
 		}
 
-		// Default to 6 digits if not specified
-		digits := service.OTP.Digits
+		digits := e.Digits
 		if digits == 0 {
 			digits = 6
 		}
 
-		// Build the key line
-		line := fmt.Sprintf("%s %d %s", name, digits, secret)
-
-		// Add counter for HOTP
-		if service.OTP.TokenType == "HOTP" {
-			line += " " + strings.Repeat("0", 20)
+		secretField := c.resolveSecretField(e.Name, raw, secret)
+		line := fmt.Sprintf("%s %d %s", e.Name, digits, secretField)
+		if e.HOTP {
+			line += " " + fmt.Sprintf("%0*d", counterLen, e.Counter)
 		}
 
 		newEntries = append(newEntries, line)
 		imported++
-		fmt.Printf("imported: %s\n", name)
+		fmt.Printf("imported: %s\n", e.Name)
 	}
 
 	if imported > 0 {
-		// Add new entries to keychain map
 		for _, line := range newEntries {
 			parts := strings.Split(line, " ")
 			if len(parts) >= 3 {
 				c.keys[parts[0]] = Key{} // Temporary entry for sorting
 			}
 		}
-
-		// Rewrite entire file in sorted order
 		if err := c.rewriteSorted(newEntries); err != nil {
 			log.Fatalf("rewriting keychain: %v", err)
 		}
@@ -104,34 +86,56 @@ func (c *Keychain) import2fas(filename string) {
 	fmt.Printf("\nSuccessfully imported %d key(s)\n", imported)
 }
 
-// getServiceName extracts the best available name for a service
-func (c *Keychain) getServiceName(service TwoFASService) string {
-	var serviceName, account string
-
-	// Get service name and replace spaces with underscores
-	if service.Name != "" {
-		serviceName = strings.ReplaceAll(service.Name, " ", "_")
-	} else if service.OTP.Issuer != "" {
-		serviceName = strings.ReplaceAll(service.OTP.Issuer, " ", "_")
+// exportTo writes every key in the keychain to filename using the given
+// format's internal/importers.Exporter. Formats that can't represent an
+// HOTP counter ("2fas" and "aegis") skip HOTP entries rather than
+// erroring, since there's nowhere in those formats to put the counter.
+func (c *Keychain) exportTo(format, filename string) {
+	exp, err := importers.LookupExporter(format)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	// Get account (keep spaces in account names)
-	if service.OTP.Account != "" {
-		account = service.OTP.Account
-	} else if service.OTP.Label != "" {
-		account = service.OTP.Label
+	var names []string
+	for name := range c.keys {
+		names = append(names, name)
 	}
-
-	// Build name in format: serviceName/account
-	if serviceName != "" && account != "" {
-		return serviceName + "/" + account
+	sort.Strings(names)
+
+	var entries []importers.Entry
+	for _, name := range names {
+		k := c.keys[name]
+		if k.backend && k.raw == nil {
+			raw, err := c.secretStore().Get(name)
+			if err != nil {
+				log.Printf("skipping %q: %v", name, err)
+				continue
+			}
+			k.raw = raw
+		}
+		e := importers.Entry{
+			Name:   name,
+			Secret: base32.StdEncoding.EncodeToString(k.raw),
+			Digits: k.digits,
+			HOTP:   k.offset != 0,
+		}
+		if e.HOTP {
+			n, err := strconv.ParseUint(string(c.data[k.offset:k.offset+counterLen]), 10, 64)
+			if err != nil {
+				log.Printf("skipping %q: malformed counter", name)
+				continue
+			}
+			e.Counter = n
+		}
+		entries = append(entries, e)
 	}
-	if serviceName != "" {
-		return serviceName
+
+	data, err := exp.Export(entries)
+	if err != nil {
+		log.Fatalf("exporting to %s: %v", format, err)
 	}
-	if account != "" {
-		return account
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		log.Fatalf("writing export file: %v", err)
 	}
-
-	return ""
+	fmt.Printf("exported %d key(s) to %s\n", len(entries), filename)
 }
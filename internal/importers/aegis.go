@@ -0,0 +1,77 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("aegis", aegis{}, aegis{})
+}
+
+// aegis imports Aegis Authenticator's plaintext JSON export (Settings >
+// Import/Export, with encryption turned off).
+type aegis struct{}
+
+type aegisExport struct {
+	DB struct {
+		Entries []aegisEntry `json:"entries"`
+	} `json:"db"`
+}
+
+type aegisEntry struct {
+	Name   string `json:"name"`
+	Issuer string `json:"issuer"`
+	Info   struct {
+		Secret string `json:"secret"`
+		Digits int    `json:"digits"`
+		Period int    `json:"period"`
+		Algo   string `json:"algo"`
+	} `json:"info"`
+}
+
+func (aegis) Import(data []byte) ([]Entry, error) {
+	var export aegisExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing aegis JSON: %v", err)
+	}
+
+	var entries []Entry
+	for _, e := range export.DB.Entries {
+		name := e.Name
+		if e.Issuer != "" {
+			name = e.Issuer + ":" + e.Name
+		}
+		name = strings.TrimSpace(name)
+		if name == "" || e.Info.Secret == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:   name,
+			Secret: e.Info.Secret,
+			Digits: e.Info.Digits,
+		})
+	}
+	return entries, nil
+}
+
+func (aegis) Export(entries []Entry) ([]byte, error) {
+	var export aegisExport
+	for _, e := range entries {
+		if e.HOTP {
+			continue // aegis's info.period assumes TOTP
+		}
+		var ae aegisEntry
+		ae.Name = e.Name
+		ae.Info.Secret = e.Secret
+		ae.Info.Digits = e.Digits
+		ae.Info.Algo = "SHA1"
+		export.DB.Entries = append(export.DB.Entries, ae)
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
@@ -0,0 +1,47 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import "testing"
+
+func TestAegisImport(t *testing.T) {
+	data := []byte(`{
+		"db": {
+			"entries": [
+				{"name": "alice", "issuer": "example.com", "info": {"secret": "JBSWY3DPEHPK3PXP", "digits": 6}},
+				{"name": "", "issuer": "", "info": {"secret": "", "digits": 6}}
+			]
+		}
+	}`)
+
+	got, err := (aegis{}).Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Import returned %d entries, want 1", len(got))
+	}
+	if got[0].Name != "example.com:alice" || got[0].Secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("entry 0 = %+v", got[0])
+	}
+}
+
+func TestAegisExportSkipsHOTP(t *testing.T) {
+	entries := []Entry{
+		{Name: "a", Secret: "JBSWY3DPEHPK3PXP", Digits: 6},
+		{Name: "b", Secret: "JBSWY3DPEHPK3PXP", Digits: 6, HOTP: true},
+	}
+	data, err := (aegis{}).Export(entries)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got, err := (aegis{}).Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "a" {
+		t.Fatalf("round trip kept the HOTP entry: %+v", got)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("andotp", andOTP{}, andOTP{})
+}
+
+// andOTP imports andOTP's plaintext JSON export (Backup > Plain Text).
+type andOTP struct{}
+
+type andOTPEntry struct {
+	Secret  string `json:"secret"`
+	Label   string `json:"label"`
+	Digits  int    `json:"digits"`
+	Period  int    `json:"period"`
+	Type    string `json:"type"` // "TOTP" or "HOTP"
+	Counter int64  `json:"counter"`
+}
+
+func (andOTP) Import(data []byte) ([]Entry, error) {
+	var export []andOTPEntry
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing andOTP JSON: %v", err)
+	}
+
+	var entries []Entry
+	for _, e := range export {
+		name := strings.TrimSpace(e.Label)
+		if name == "" || e.Secret == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:    name,
+			Secret:  e.Secret,
+			Digits:  e.Digits,
+			HOTP:    strings.EqualFold(e.Type, "HOTP"),
+			Counter: uint64(e.Counter),
+		})
+	}
+	return entries, nil
+}
+
+func (andOTP) Export(entries []Entry) ([]byte, error) {
+	var export []andOTPEntry
+	for _, e := range entries {
+		typ := "TOTP"
+		if e.HOTP {
+			typ = "HOTP"
+		}
+		export = append(export, andOTPEntry{
+			Secret:  e.Secret,
+			Label:   e.Name,
+			Digits:  e.Digits,
+			Type:    typ,
+			Counter: int64(e.Counter),
+		})
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
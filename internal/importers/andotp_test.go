@@ -0,0 +1,43 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import "testing"
+
+func TestAndOTPRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Name: "alice", Secret: "JBSWY3DPEHPK3PXP", Digits: 6},
+		{Name: "bob", Secret: "JBSWY3DPEHPK3PXP", Digits: 8, HOTP: true, Counter: 7},
+	}
+
+	data, err := (andOTP{}).Export(entries)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got, err := (andOTP{}).Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("Import returned %d entries, want %d", len(got), len(entries))
+	}
+	for i, e := range entries {
+		if got[i].Name != e.Name || got[i].Secret != e.Secret || got[i].Digits != e.Digits ||
+			got[i].HOTP != e.HOTP || got[i].Counter != e.Counter {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], e)
+		}
+	}
+}
+
+func TestAndOTPImportSkipsEmptyLabel(t *testing.T) {
+	data := []byte(`[{"secret": "JBSWY3DPEHPK3PXP", "label": "", "digits": 6, "type": "TOTP"}]`)
+	got, err := (andOTP{}).Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("Import returned %d entries, want 0", len(got))
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package importers implements 2fa's pluggable import/export formats.
+// Each format registers an Importer and, where the format supports it, an
+// Exporter under its format name ("2fas", "otpauth", "otpauth-migration",
+// "aegis", "andotp"); main looks the name up rather than switching on it.
+package importers
+
+import "fmt"
+
+// Entry is a single account decoded from (or to be encoded into) an
+// external export format. It mirrors the fields of a keychain line.
+type Entry struct {
+	Name    string // keychain name, e.g. "issuer:account"
+	Secret  string // base32 secret, as stored in the keychain file
+	Digits  int    // 0 means "format didn't say, use the default of 6"
+	HOTP    bool
+	Counter uint64 // valid only when HOTP is true
+}
+
+// Importer decodes an external export file into keychain entries.
+type Importer interface {
+	// Import parses the raw contents of an export file.
+	Import(data []byte) ([]Entry, error)
+}
+
+// Exporter encodes keychain entries into an external export format. Not
+// every format can round-trip; formats without HOTP support should drop
+// HOTP entries rather than error, and say so via a returned warning isn't
+// part of the interface, so callers should filter before calling Export if
+// they want to report skips themselves.
+type Exporter interface {
+	Export(entries []Entry) ([]byte, error)
+}
+
+var (
+	importersByName = map[string]Importer{}
+	exportersByName = map[string]Exporter{}
+)
+
+// register adds a format to the registry. It is called from each format's
+// init function, the same pattern database/sql drivers use.
+func register(name string, i Importer, e Exporter) {
+	if i != nil {
+		importersByName[name] = i
+	}
+	if e != nil {
+		exportersByName[name] = e
+	}
+}
+
+// Lookup returns the Importer registered for format, if any.
+func Lookup(format string) (Importer, error) {
+	i, ok := importersByName[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	return i, nil
+}
+
+// LookupExporter returns the Exporter registered for format, if any.
+func LookupExporter(format string) (Exporter, error) {
+	e, ok := exportersByName[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+	return e, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+
+	"github.com/jammutkarsh/2fa/internal/otpauth"
+)
+
+func init() {
+	register("otpauth", otpauthFormat{}, otpauthFormat{})
+}
+
+// otpauthFormat imports/exports one otpauth://totp|hotp/... URI per
+// non-blank line of the file.
+type otpauthFormat struct{}
+
+func (otpauthFormat) Import(data []byte) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		u, err := otpauth.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		entries = append(entries, Entry{
+			Name:    u.Name(),
+			Secret:  u.Secret,
+			Digits:  u.Digits,
+			HOTP:    u.Type == "hotp",
+			Counter: u.Counter,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading otpauth file: %v", err)
+	}
+	return entries, nil
+}
+
+func (otpauthFormat) Export(entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		u := otpauth.URI{
+			Type:    "totp",
+			Account: e.Name,
+			Secret:  e.Secret,
+			Digits:  e.Digits,
+		}
+		if e.HOTP {
+			u.Type = "hotp"
+			u.Counter = e.Counter
+		}
+		buf.WriteString(u.String())
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
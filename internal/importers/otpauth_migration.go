@@ -0,0 +1,158 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	register("otpauth-migration", otpauthMigration{}, nil)
+}
+
+// otpauthMigration imports Google Authenticator's "Export accounts" QR
+// code payload: one otpauth-migration://offline?data=<base64> URI per
+// non-blank line, where data is a protobuf-encoded MigrationPayload.
+//
+// MigrationPayload (fields relevant to us; others are ignored):
+//
+//	message MigrationPayload {
+//	  repeated OtpParameters otp_parameters = 1;
+//	}
+//	message OtpParameters {
+//	  bytes secret = 1;
+//	  string name = 2;
+//	  string issuer = 3;
+//	  Algorithm algorithm = 4;  // 1=SHA1, 2=SHA256, 3=SHA512, 4=MD5
+//	  DigitCount digits = 5;    // 1=SIX, 2=EIGHT
+//	  OtpType type = 6;         // 1=HOTP, 2=TOTP
+//	  int64 counter = 7;
+//	}
+//
+// The message is small enough that vendoring a full protobuf library isn't
+// worth it; readVarint, walkProto and protoFields in proto.go implement just
+// enough of the wire format (varints and length-delimited fields) to walk it.
+type otpauthMigration struct{}
+
+func (otpauthMigration) Import(data []byte) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		es, err := parseMigrationURI(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		entries = append(entries, es...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading otpauth-migration file: %v", err)
+	}
+	return entries, nil
+}
+
+func parseMigrationURI(raw string) ([]Entry, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "otpauth-migration" {
+		return nil, fmt.Errorf("not an otpauth-migration URI: %q", raw)
+	}
+	payload := u.Query().Get("data")
+	if payload == "" {
+		return nil, fmt.Errorf("otpauth-migration URI missing data")
+	}
+	// The payload is URL-safe in some exports and standard in others;
+	// accept either.
+	payload = strings.ReplaceAll(strings.ReplaceAll(payload, "-", "+"), "_", "/")
+	raw2, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		raw2, err = base64.RawStdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed base64 payload: %v", err)
+		}
+	}
+
+	otpParamsList, err := protoFields(raw2, 1)
+	if err != nil {
+		return nil, fmt.Errorf("malformed migration payload: %v", err)
+	}
+
+	var entries []Entry
+	for _, otpParams := range otpParamsList {
+		e, err := decodeOtpParameters(otpParams)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+const (
+	migAlgoSHA1  = 1
+	migDigitsSix = 1
+	migTypeHOTP  = 1
+	migTypeTOTP  = 2
+)
+
+func decodeOtpParameters(msg []byte) (Entry, error) {
+	var e Entry
+	var algo, digitCount, otpType int64
+	var counter int64
+	var secret []byte
+	var name, issuer string
+
+	err := walkProto(msg, func(field int, wire int, raw []byte, varint int64) error {
+		switch field {
+		case 1: // secret
+			secret = raw
+		case 2: // name
+			name = string(raw)
+		case 3: // issuer
+			issuer = string(raw)
+		case 4: // algorithm
+			algo = varint
+		case 5: // digits
+			digitCount = varint
+		case 6: // type
+			otpType = varint
+		case 7: // counter
+			counter = varint
+		}
+		return nil
+	})
+	if err != nil {
+		return e, err
+	}
+	if len(secret) == 0 {
+		return e, fmt.Errorf("otpauth-migration entry missing secret")
+	}
+
+	e.Name = name
+	if issuer != "" {
+		e.Name = issuer + ":" + name
+	}
+	e.Secret = base32NoPad(secret)
+	e.Digits = 6
+	if digitCount == 2 {
+		e.Digits = 8
+	}
+	e.HOTP = otpType == migTypeHOTP
+	e.Counter = uint64(counter)
+	if algo != 0 && algo != migAlgoSHA1 {
+		log.Printf("warning: %q uses a non-SHA1 algorithm, which 2fa can't represent; the imported key won't generate matching codes", e.Name)
+	}
+	return e, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// encodeVarint and encodeField build just enough of the protobuf wire
+// format to construct MigrationPayload test fixtures; they're the inverse
+// of readVarint/walkProto and exist only for these tests.
+func encodeVarint(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+		} else {
+			out = append(out, b)
+			return out
+		}
+	}
+}
+
+func encodeTag(field, wire int) []byte {
+	return encodeVarint(uint64(field)<<3 | uint64(wire))
+}
+
+func encodeBytesField(field int, data []byte) []byte {
+	out := encodeTag(field, 2)
+	out = append(out, encodeVarint(uint64(len(data)))...)
+	return append(out, data...)
+}
+
+func encodeVarintField(field int, v uint64) []byte {
+	return append(encodeTag(field, 0), encodeVarint(v)...)
+}
+
+func encodeOtpParameters(secret []byte, name, issuer string, digitCount, otpType int64, counter uint64) []byte {
+	var out []byte
+	out = append(out, encodeBytesField(1, secret)...)
+	out = append(out, encodeBytesField(2, []byte(name))...)
+	out = append(out, encodeBytesField(3, []byte(issuer))...)
+	out = append(out, encodeVarintField(5, uint64(digitCount))...)
+	out = append(out, encodeVarintField(6, uint64(otpType))...)
+	out = append(out, encodeVarintField(7, counter)...)
+	return out
+}
+
+func TestOtpauthMigrationImport(t *testing.T) {
+	secret := []byte{0x48, 0x65, 0x6c, 0x6c, 0x6f} // "Hello"
+	params := encodeOtpParameters(secret, "alice", "example.com", migDigitsSix, migTypeTOTP, 0)
+	payload := encodeBytesField(1, params)
+
+	uri := "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString(payload)
+
+	entries, err := parseMigrationURI(uri)
+	if err != nil {
+		t.Fatalf("parseMigrationURI: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "example.com:alice" || e.Digits != 6 || e.HOTP {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Secret != base32NoPad(secret) {
+		t.Errorf("entry.Secret = %q, want %q", e.Secret, base32NoPad(secret))
+	}
+}
+
+func TestOtpauthMigrationImportHOTP(t *testing.T) {
+	secret := []byte{0x01, 0x02, 0x03, 0x04}
+	params := encodeOtpParameters(secret, "bob", "", migDigitsSix, migTypeHOTP, 99)
+	payload := encodeBytesField(1, params)
+	uri := "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString(payload)
+
+	entries, err := parseMigrationURI(uri)
+	if err != nil {
+		t.Fatalf("parseMigrationURI: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e.Name != "bob" || !e.HOTP || e.Counter != 99 {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestOtpauthMigrationImportMissingSecret(t *testing.T) {
+	params := encodeOtpParameters(nil, "alice", "", migDigitsSix, migTypeTOTP, 0)
+	payload := encodeBytesField(1, params)
+	uri := "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString(payload)
+
+	if _, err := parseMigrationURI(uri); err == nil {
+		t.Fatal("parseMigrationURI: got nil error for missing secret, want one")
+	}
+}
+
+func TestOtpauthMigrationImportWrongScheme(t *testing.T) {
+	if _, err := parseMigrationURI("otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP"); err == nil {
+		t.Fatal("parseMigrationURI: got nil error for wrong scheme, want one")
+	}
+}
+
+// TestOtpauthMigrationImportWarnsOnNonSHA1Algorithm locks in that a
+// migrated entry using an algorithm other than SHA1 is still imported
+// (2fa can't represent it) but logs a warning explaining why it won't
+// generate matching codes, rather than silently dropping the fact.
+func TestOtpauthMigrationImportWarnsOnNonSHA1Algorithm(t *testing.T) {
+	const migAlgoSHA256 = 2
+	params := encodeOtpParameters([]byte{0x01, 0x02}, "alice", "", migDigitsSix, migTypeTOTP, 0)
+	params = append(params, encodeVarintField(4, migAlgoSHA256)...)
+	payload := encodeBytesField(1, params)
+	uri := "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString(payload)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	entries, err := parseMigrationURI(uri)
+	if err != nil {
+		t.Fatalf("parseMigrationURI: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if !strings.Contains(buf.String(), "non-SHA1") {
+		t.Fatalf("expected a non-SHA1 warning to be logged, got: %q", buf.String())
+	}
+}
+
+// TestOtpauthMigrationImportTruncatedPayload locks in that a corrupt
+// payload surfaces as an error from parseMigrationURI rather than
+// silently yielding fewer (or zero) entries.
+func TestOtpauthMigrationImportTruncatedPayload(t *testing.T) {
+	// field 1, wire type 2, followed by an oversized length varint
+	payload := []byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	uri := "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString(payload)
+
+	if _, err := parseMigrationURI(uri); err == nil {
+		t.Fatal("parseMigrationURI: got nil error for truncated payload, want one")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import "testing"
+
+func TestOtpauthRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Name: "example.com:alice", Secret: "JBSWY3DPEHPK3PXP", Digits: 6},
+		{Name: "example.com:bob", Secret: "JBSWY3DPEHPK3PXP", Digits: 8, HOTP: true, Counter: 42},
+	}
+
+	data, err := otpauthFormat{}.Export(entries)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	got, err := otpauthFormat{}.Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("Import returned %d entries, want %d", len(got), len(entries))
+	}
+	for i, e := range entries {
+		if got[i].Name != e.Name || got[i].Secret != e.Secret || got[i].Digits != e.Digits ||
+			got[i].HOTP != e.HOTP || got[i].Counter != e.Counter {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], e)
+		}
+	}
+}
+
+func TestOtpauthImportMalformedLine(t *testing.T) {
+	if _, err := (otpauthFormat{}).Import([]byte("not a uri\n")); err == nil {
+		t.Fatal("Import: got nil error for malformed line, want one")
+	}
+}
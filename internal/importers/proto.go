@@ -0,0 +1,108 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"encoding/base32"
+	"fmt"
+)
+
+// This file is a hand-rolled reader for just enough of the protobuf wire
+// format (varints, length-delimited bytes, and the 32/64-bit fixed widths
+// we need to skip over) to walk Google Authenticator's tiny
+// otpauth-migration payload. It is not a general-purpose protobuf decoder:
+// there's no schema, no nested-message typing, and no support for packed
+// repeated scalar fields beyond what otpauth-migration happens to use.
+
+// walkProto calls fn once per top-level varint or length-delimited field in
+// msg; 32- and 64-bit fixed-width fields are skipped without calling fn.
+// For varint fields, raw is nil and varint holds the decoded value. For
+// length-delimited fields (strings, bytes, embedded messages), raw holds
+// the field bytes and varint is 0.
+func walkProto(msg []byte, fn func(field, wire int, raw []byte, varint int64) error) error {
+	i := 0
+	for i < len(msg) {
+		tag, n, err := readVarint(msg[i:])
+		if err != nil {
+			return fmt.Errorf("malformed protobuf tag: %v", err)
+		}
+		i += n
+		field, wire := int(tag>>3), int(tag&0x7)
+
+		switch wire {
+		case 0: // varint
+			v, n, err := readVarint(msg[i:])
+			if err != nil {
+				return fmt.Errorf("malformed protobuf varint: %v", err)
+			}
+			i += n
+			if err := fn(field, wire, nil, int64(v)); err != nil {
+				return err
+			}
+		case 1: // 64-bit
+			if i+8 > len(msg) {
+				return fmt.Errorf("truncated protobuf fixed64")
+			}
+			i += 8
+		case 2: // length-delimited
+			length, n, err := readVarint(msg[i:])
+			if err != nil {
+				return fmt.Errorf("malformed protobuf length: %v", err)
+			}
+			i += n
+			if length > uint64(len(msg)-i) {
+				return fmt.Errorf("truncated protobuf field")
+			}
+			if err := fn(field, wire, msg[i:i+int(length)], 0); err != nil {
+				return err
+			}
+			i += int(length)
+		case 5: // 32-bit
+			if i+4 > len(msg) {
+				return fmt.Errorf("truncated protobuf fixed32")
+			}
+			i += 4
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wire)
+		}
+	}
+	return nil
+}
+
+// protoFields returns the raw bytes of every length-delimited occurrence of
+// field in msg, in order. It's used to pull out the repeated
+// "otp_parameters" sub-messages.
+func protoFields(msg []byte, field int) ([][]byte, error) {
+	var out [][]byte
+	err := walkProto(msg, func(f, wire int, raw []byte, _ int64) error {
+		if f == field && wire == 2 {
+			out = append(out, raw)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readVarint decodes a base-128 varint from the start of b, returning the
+// value and the number of bytes consumed.
+func readVarint(b []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated varint")
+}
+
+// base32NoPad encodes raw the way otpauth-migration secrets are conventionally
+// represented in the keychain file: unpadded, uppercase base32.
+func base32NoPad(raw []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import "testing"
+
+func TestReadVarint(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      []byte
+		want    uint64
+		wantN   int
+		wantErr bool
+	}{
+		{"zero", []byte{0x00}, 0, 1, false},
+		{"single byte", []byte{0x01}, 1, 1, false},
+		{"two bytes", []byte{0x96, 0x01}, 150, 2, false},
+		{"empty", nil, 0, 0, true},
+		{"unterminated", []byte{0x80, 0x80, 0x80}, 0, 0, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, n, err := readVarint(c.in)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("readVarint(%v): got nil error, want one", c.in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readVarint(%v): unexpected error: %v", c.in, err)
+			}
+			if got != c.want || n != c.wantN {
+				t.Fatalf("readVarint(%v) = %d, %d; want %d, %d", c.in, got, n, c.want, c.wantN)
+			}
+		})
+	}
+}
+
+func TestWalkProtoLengthDelimited(t *testing.T) {
+	// field 1, wire type 2, length 3, bytes "abc"
+	msg := []byte{0x0a, 0x03, 'a', 'b', 'c'}
+	var got []byte
+	err := walkProto(msg, func(field, wire int, raw []byte, varint int64) error {
+		if field != 1 || wire != 2 {
+			t.Fatalf("unexpected field %d wire %d", field, wire)
+		}
+		got = raw
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkProto: unexpected error: %v", err)
+	}
+	if string(got) != "abc" {
+		t.Fatalf("walkProto: got %q, want %q", got, "abc")
+	}
+}
+
+func TestWalkProtoTruncatedLength(t *testing.T) {
+	// field 1, wire type 2, length says 10 bytes follow but only 1 is present
+	msg := []byte{0x0a, 0x0a, 'x'}
+	if err := walkProto(msg, func(int, int, []byte, int64) error { return nil }); err == nil {
+		t.Fatal("walkProto: got nil error for truncated field, want one")
+	}
+}
+
+// TestWalkProtoOversizedLength reproduces the fix for a length varint whose
+// value narrows to a negative int: msg[i:i+int(length)] must not panic, it
+// must return an error.
+func TestWalkProtoOversizedLength(t *testing.T) {
+	// field 1, wire type 2, followed by a max-width varint whose low 64 bits
+	// decode to a value >= 2^63 so int(length) is negative.
+	msg := []byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	err := walkProto(msg, func(int, int, []byte, int64) error { return nil })
+	if err == nil {
+		t.Fatal("walkProto: got nil error for oversized length, want one")
+	}
+}
+
+func TestWalkProtoUnsupportedWireType(t *testing.T) {
+	// field 1, wire type 3 (start group), unsupported
+	msg := []byte{0x0b}
+	if err := walkProto(msg, func(int, int, []byte, int64) error { return nil }); err == nil {
+		t.Fatal("walkProto: got nil error for unsupported wire type, want one")
+	}
+}
+
+func TestProtoFields(t *testing.T) {
+	// two length-delimited occurrences of field 1: "ab" and "cde"
+	msg := []byte{0x0a, 0x02, 'a', 'b', 0x0a, 0x03, 'c', 'd', 'e'}
+	got, err := protoFields(msg, 1)
+	if err != nil {
+		t.Fatalf("protoFields: unexpected error: %v", err)
+	}
+	if len(got) != 2 || string(got[0]) != "ab" || string(got[1]) != "cde" {
+		t.Fatalf("protoFields = %v, want [ab cde]", got)
+	}
+}
+
+func TestProtoFieldsPropagatesError(t *testing.T) {
+	// a malformed length on the second occurrence of field 1
+	msg := []byte{0x0a, 0x02, 'a', 'b', 0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	if _, err := protoFields(msg, 1); err == nil {
+		t.Fatal("protoFields: got nil error for malformed field, want one")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import "testing"
+
+func TestTwoFASImport(t *testing.T) {
+	data := []byte(`{
+		"services": [
+			{
+				"name": "Example",
+				"secret": "JBSWY3DPEHPK3PXP",
+				"otp": {"account": "alice", "digits": 6, "tokenType": "TOTP"}
+			},
+			{
+				"secret": "JBSWY3DPEHPK3PXP",
+				"otp": {"issuer": "Other", "label": "bob", "digits": 8, "tokenType": "HOTP"}
+			},
+			{
+				"secret": "JBSWY3DPEHPK3PXP",
+				"otp": {"digits": 6, "tokenType": "TOTP"}
+			}
+		]
+	}`)
+
+	got, err := (twoFAS{}).Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	// the third service has no name or issuer, so it's dropped
+	if len(got) != 2 {
+		t.Fatalf("Import returned %d entries, want 2", len(got))
+	}
+	if got[0].Name != "Example/alice" || got[0].HOTP {
+		t.Errorf("entry 0 = %+v", got[0])
+	}
+	if got[1].Name != "Other/bob" || !got[1].HOTP || got[1].Digits != 8 {
+		t.Errorf("entry 1 = %+v", got[1])
+	}
+}
+
+func TestTwoFASExportSkipsHOTP(t *testing.T) {
+	entries := []Entry{
+		{Name: "a", Secret: "JBSWY3DPEHPK3PXP", Digits: 6},
+		{Name: "b", Secret: "JBSWY3DPEHPK3PXP", Digits: 6, HOTP: true, Counter: 1},
+	}
+	data, err := (twoFAS{}).Export(entries)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got, err := (twoFAS{}).Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "a/a" {
+		t.Fatalf("round trip kept the HOTP entry: %+v", got)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package otpauth parses and serializes "otpauth://" key provisioning URIs,
+// per Google Authenticator's KeyURIFormat spec
+// (https://github.com/google/google-authenticator/wiki/Key-Uri-Format).
+// It is the single implementation shared by the otpauth importer/exporter
+// and by the QR enrollment commands, so all three agree on how a URI maps
+// to a keychain entry.
+package otpauth
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URI is a parsed otpauth:// key provisioning URI.
+type URI struct {
+	Type      string // "totp" or "hotp"
+	Issuer    string
+	Account   string
+	Secret    string // base32, as it appears in the URI
+	Digits    int    // 0 means "unspecified, caller should default to 6"
+	Period    int    // TOTP step in seconds; 0 means "unspecified, default 30"
+	Algorithm string // "SHA1", "SHA256" or "SHA512"; "" means "unspecified, default SHA1"
+	Counter   uint64 // HOTP only
+}
+
+// Parse decodes an otpauth://totp/... or otpauth://hotp/... URI.
+func Parse(raw string) (*URI, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed otpauth URI: %v", err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("not an otpauth URI: %q", raw)
+	}
+	typ := strings.ToLower(u.Host)
+	if typ != "totp" && typ != "hotp" {
+		return nil, fmt.Errorf("unsupported otpauth type %q", typ)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	label, err = url.PathUnescape(label)
+	if err != nil {
+		return nil, fmt.Errorf("malformed otpauth label: %v", err)
+	}
+	issuer, account := "", label
+	if i := strings.Index(label, ":"); i >= 0 {
+		issuer, account = label[:i], strings.TrimLeft(label[i+1:], " ")
+	}
+
+	q := u.Query()
+	if q.Get("issuer") != "" {
+		issuer = q.Get("issuer")
+	}
+
+	out := &URI{
+		Type:      typ,
+		Issuer:    issuer,
+		Account:   account,
+		Secret:    q.Get("secret"),
+		Algorithm: strings.ToUpper(q.Get("algorithm")),
+	}
+	if out.Secret == "" {
+		return nil, fmt.Errorf("otpauth URI missing secret")
+	}
+	if v := q.Get("digits"); v != "" {
+		out.Digits, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("malformed digits %q: %v", v, err)
+		}
+	}
+	if v := q.Get("period"); v != "" {
+		out.Period, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("malformed period %q: %v", v, err)
+		}
+	}
+	if typ == "hotp" {
+		v := q.Get("counter")
+		if v == "" {
+			return nil, fmt.Errorf("otpauth hotp URI missing counter")
+		}
+		out.Counter, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed counter %q: %v", v, err)
+		}
+	}
+	return out, nil
+}
+
+// Name returns the conventional "issuer:account" keychain name for u,
+// falling back to just the account (or issuer) when the other is absent.
+func (u *URI) Name() string {
+	switch {
+	case u.Issuer != "" && u.Account != "":
+		return u.Issuer + ":" + u.Account
+	case u.Issuer != "":
+		return u.Issuer
+	default:
+		return u.Account
+	}
+}
+
+// String serializes u back into an otpauth:// provisioning URI.
+func (u *URI) String() string {
+	label := u.Account
+	if u.Issuer != "" {
+		label = u.Issuer + ":" + u.Account
+	}
+	q := url.Values{}
+	q.Set("secret", u.Secret)
+	if u.Issuer != "" {
+		q.Set("issuer", u.Issuer)
+	}
+	if u.Digits != 0 {
+		q.Set("digits", strconv.Itoa(u.Digits))
+	}
+	if u.Algorithm != "" {
+		q.Set("algorithm", u.Algorithm)
+	}
+	if u.Type == "totp" && u.Period != 0 {
+		q.Set("period", strconv.Itoa(u.Period))
+	}
+	if u.Type == "hotp" {
+		q.Set("counter", strconv.FormatUint(u.Counter, 10))
+	}
+
+	out := url.URL{
+		Scheme:   "otpauth",
+		Host:     u.Type,
+		Path:     "/" + label,
+		RawQuery: q.Encode(),
+	}
+	return out.String()
+}
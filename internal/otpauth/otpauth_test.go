@@ -0,0 +1,66 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package otpauth
+
+import "testing"
+
+func TestParseSerializeRoundTrip(t *testing.T) {
+	cases := []*URI{
+		{Type: "totp", Issuer: "example.com", Account: "alice", Secret: "JBSWY3DPEHPK3PXP", Digits: 8, Algorithm: "SHA256", Period: 60},
+		{Type: "hotp", Account: "bob", Secret: "JBSWY3DPEHPK3PXP", Counter: 42},
+	}
+	for _, u := range cases {
+		raw := u.String()
+		got, err := Parse(raw)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", raw, err)
+		}
+		if got.Type != u.Type || got.Issuer != u.Issuer || got.Account != u.Account ||
+			got.Secret != u.Secret || got.Digits != u.Digits || got.Algorithm != u.Algorithm ||
+			got.Period != u.Period || got.Counter != u.Counter {
+			t.Errorf("round trip: got %+v, want %+v", got, u)
+		}
+	}
+}
+
+func TestParseRejectsWrongScheme(t *testing.T) {
+	if _, err := Parse("otpauth-migration://offline?data=x"); err == nil {
+		t.Fatal("Parse: got nil error for wrong scheme, want one")
+	}
+}
+
+func TestParseRejectsUnsupportedType(t *testing.T) {
+	if _, err := Parse("otpauth://motp/alice?secret=JBSWY3DPEHPK3PXP"); err == nil {
+		t.Fatal("Parse: got nil error for unsupported type, want one")
+	}
+}
+
+func TestParseRequiresSecret(t *testing.T) {
+	if _, err := Parse("otpauth://totp/alice"); err == nil {
+		t.Fatal("Parse: got nil error for missing secret, want one")
+	}
+}
+
+func TestParseHOTPRequiresCounter(t *testing.T) {
+	if _, err := Parse("otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP"); err == nil {
+		t.Fatal("Parse: got nil error for missing hotp counter, want one")
+	}
+}
+
+func TestName(t *testing.T) {
+	cases := []struct {
+		u    URI
+		want string
+	}{
+		{URI{Issuer: "example.com", Account: "alice"}, "example.com:alice"},
+		{URI{Issuer: "example.com"}, "example.com"},
+		{URI{Account: "alice"}, "alice"},
+	}
+	for _, c := range cases {
+		if got := c.u.Name(); got != c.want {
+			t.Errorf("Name() = %q, want %q", got, c.want)
+		}
+	}
+}
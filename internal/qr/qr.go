@@ -0,0 +1,76 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package qr wraps a pure-Go QR encoder and decoder so the rest of 2fa
+// doesn't need to know which libraries do the actual bit-twiddling. It
+// backs both "2fa -add -qr" (decode) and "2fa -qr name" (render).
+package qr
+
+import (
+	"image"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	qrencode "github.com/skip2/go-qrcode"
+)
+
+// Decode reads the QR code in img and returns the text it encodes.
+func Decode(img image.Image) (string, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.GetText(), nil
+}
+
+// RenderASCII renders text as a QR code using Unicode half-block
+// characters, two QR modules per output row, so it reads as roughly
+// square in a typical monospace terminal.
+func RenderASCII(text string) (string, error) {
+	q, err := qrencode.New(text, qrencode.Medium)
+	if err != nil {
+		return "", err
+	}
+	return renderBitmap(q.Bitmap()), nil
+}
+
+// renderBitmap packs bitmap (true = dark module) two rows at a time into
+// ▀ ▄ █ and space, with a 2-module quiet zone so scanners reading the
+// rendered output off a screen don't choke on a tight border.
+func renderBitmap(bitmap [][]bool) string {
+	const quiet = 2
+	size := len(bitmap) + quiet*2
+
+	pix := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		if x < 0 || y < 0 || y >= len(bitmap) || x >= len(bitmap[y]) {
+			return false
+		}
+		return bitmap[y][x]
+	}
+
+	var buf strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top, bottom := pix(x, y), pix(x, y+1)
+			switch {
+			case top && bottom:
+				buf.WriteRune('█')
+			case top:
+				buf.WriteRune('▀')
+			case bottom:
+				buf.WriteRune('▄')
+			default:
+				buf.WriteRune(' ')
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
@@ -0,0 +1,294 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// encryptedKeychain is the on-disk JSON envelope for a passphrase-protected
+// keychain. It is modeled on the Ethereum web3 keystore format: a KDF
+// (scrypt) derives a key from the user's passphrase, the first half of the
+// key encrypts the keychain body and the second half authenticates it via
+// HMAC-SHA256, so the two halves are independent even though GCM already
+// authenticates the ciphertext on its own.
+type encryptedKeychain struct {
+	Version      int          `json:"version"`
+	Cipher       string       `json:"cipher"`
+	CipherParams cipherParams `json:"cipherparams"`
+	KDF          string       `json:"kdf"`
+	KDFParams    scryptParams `json:"kdfparams"`
+	CipherText   string       `json:"ciphertext"`
+	MAC          string       `json:"mac"`
+}
+
+// cipherParams holds the IV/nonce for whichever cipher produced CipherText.
+type cipherParams struct {
+	IV string `json:"iv"`
+}
+
+// scryptParams are the parameters used to derive the encryption key from
+// the user's passphrase.
+type scryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+const (
+	defaultScryptN     = 1 << 18
+	defaultScryptR     = 8
+	defaultScryptP     = 1
+	defaultScryptDKLen = 64 // 32 bytes for the cipher key, 32 for the MAC key
+)
+
+// isEncryptedKeychain reports whether data is an encrypted keychain
+// envelope rather than the legacy plaintext "name digits secret" format.
+// It sniffs the first non-whitespace byte: plaintext keychains start with
+// a key name, JSON envelopes start with '{'.
+func isEncryptedKeychain(data []byte) bool {
+	for _, b := range data {
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		default:
+			return b == '{'
+		}
+	}
+	return false
+}
+
+// deriveKey runs scrypt over passphrase using p, returning a p.DKLen-byte
+// key. The first half of the key is used for encryption, the second half
+// for the MAC.
+func deriveKey(passphrase string, p scryptParams) ([]byte, error) {
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("malformed salt: %v", err)
+	}
+	return scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, p.DKLen)
+}
+
+// computeMAC authenticates ciphertext with HMAC-SHA256 keyed by the second
+// half of key, kept independent of whatever half the cipher uses.
+func computeMAC(key, ciphertext []byte) []byte {
+	h := hmac.New(sha256.New, key[len(key)/2:])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+// encryptData derives a fresh key from passphrase (new salt every call) and
+// seals plaintext as an aes-256-gcm envelope.
+func encryptData(passphrase string, plaintext []byte) (*encryptedKeychain, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := scryptParams{
+		N:     defaultScryptN,
+		R:     defaultScryptR,
+		P:     defaultScryptP,
+		DKLen: defaultScryptDKLen,
+		Salt:  hex.EncodeToString(salt),
+	}
+	key, err := deriveKey(passphrase, params)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	return &encryptedKeychain{
+		Version:      1,
+		Cipher:       "aes-256-gcm",
+		CipherParams: cipherParams{IV: hex.EncodeToString(nonce)},
+		KDF:          "scrypt",
+		KDFParams:    params,
+		CipherText:   base64.StdEncoding.EncodeToString(ciphertext),
+		MAC:          hex.EncodeToString(computeMAC(key, ciphertext)),
+	}, nil
+}
+
+// decryptData recovers the plaintext keychain body from e using passphrase,
+// rejecting the result if the MAC doesn't match (wrong passphrase or
+// tampered file). Both cipher variants named in the envelope format are
+// supported, though encryptData only ever produces aes-256-gcm.
+func decryptData(passphrase string, e *encryptedKeychain) ([]byte, error) {
+	if e.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported kdf %q", e.KDF)
+	}
+	key, err := deriveKey(passphrase, e.KDFParams)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(e.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("malformed ciphertext: %v", err)
+	}
+	wantMAC, err := hex.DecodeString(e.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("malformed mac: %v", err)
+	}
+	if subtle.ConstantTimeCompare(computeMAC(key, ciphertext), wantMAC) != 1 {
+		return nil, fmt.Errorf("incorrect passphrase or corrupt keychain")
+	}
+	iv, err := hex.DecodeString(e.CipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("malformed iv: %v", err)
+	}
+
+	switch e.Cipher {
+	case "aes-256-gcm":
+		block, err := aes.NewCipher(key[:len(key)/2])
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return gcm.Open(nil, iv, ciphertext, nil)
+	case "aes-128-ctr":
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCTR(block, iv).XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+	default:
+		return nil, fmt.Errorf("unsupported cipher %q", e.Cipher)
+	}
+}
+
+// readPassphrase prints prompt to stderr and reads a line from the
+// terminal without echoing it.
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readNewPassphrase prompts twice and requires the two entries to match,
+// for the one-time "enable encryption" flow.
+func readNewPassphrase() (string, error) {
+	p1, err := readPassphrase("new 2fa passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	p2, err := readPassphrase("confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if p1 != p2 {
+		return "", fmt.Errorf("passphrases did not match")
+	}
+	return p1, nil
+}
+
+// loadEncrypted parses raw as an encrypted keychain envelope, prompts for
+// the passphrase, and returns the decrypted plaintext body along with the
+// passphrase (kept in memory so later writes can re-encrypt without
+// prompting again).
+func loadEncrypted(raw []byte) (plaintext []byte, passphrase string) {
+	var envelope encryptedKeychain
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		log.Fatalf("malformed encrypted keychain: %v", err)
+	}
+	passphrase, err := readPassphrase("2fa keychain passphrase: ")
+	if err != nil {
+		log.Fatalf("reading passphrase: %v", err)
+	}
+	plaintext, err = decryptData(passphrase, &envelope)
+	if err != nil {
+		log.Fatalf("decrypting keychain: %v", err)
+	}
+	return plaintext, passphrase
+}
+
+// enableEncryption converts a plaintext keychain into a passphrase-protected
+// one, prompting for (and confirming) a new passphrase and immediately
+// rewriting the keychain file as an encrypted envelope.
+func (c *Keychain) enableEncryption() {
+	if c.encrypted {
+		log.Fatalf("keychain is already encrypted")
+	}
+	passphrase, err := readNewPassphrase()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	c.encrypted = true
+	c.passphrase = passphrase
+	if err := c.rewriteSorted(nil); err != nil {
+		log.Fatalf("encrypting keychain: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "keychain encrypted\n")
+}
+
+// writeEncrypted re-encrypts the in-memory plaintext body under c.passphrase
+// and atomically replaces c.file with the new envelope.
+func (c *Keychain) writeEncrypted() error {
+	envelope, err := encryptData(c.passphrase, c.data)
+	if err != nil {
+		return fmt.Errorf("encrypting keychain: %v", err)
+	}
+	js, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling keychain: %v", err)
+	}
+	tmp := c.file + ".tmp"
+	if err := os.WriteFile(tmp, js, 0600); err != nil {
+		return fmt.Errorf("writing keychain: %v", err)
+	}
+	if err := os.Rename(tmp, c.file); err != nil {
+		return fmt.Errorf("renaming keychain: %v", err)
+	}
+	return nil
+}
+
+// updateCounter rewrites name's HOTP counter to n in the in-memory
+// plaintext and re-encrypts the keychain. Encrypted keychains cannot patch
+// the counter bytes of the ciphertext in place the way plaintext ones do
+// with WriteAt (see Keychain.code), so this goes through a full
+// re-encrypt-and-rename instead.
+func (c *Keychain) updateCounter(name string, n uint64) {
+	k := c.keys[name]
+	copy(c.data[k.offset:k.offset+counterLen], []byte(fmt.Sprintf("%0*d", counterLen, n)))
+	if err := c.writeEncrypted(); err != nil {
+		log.Fatalf("updating keychain: %v", err)
+	}
+}
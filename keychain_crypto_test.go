@@ -0,0 +1,70 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	plaintext := []byte("alice 6 JBSWY3DPEHPK3PXP\n")
+	envelope, err := encryptData("hunter2", plaintext)
+	if err != nil {
+		t.Fatalf("encryptData: %v", err)
+	}
+
+	got, err := decryptData("hunter2", envelope)
+	if err != nil {
+		t.Fatalf("decryptData: %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Fatalf("decryptData = %q, want %q", got, plaintext)
+	}
+}
+
+func TestDecryptWrongPassphrase(t *testing.T) {
+	envelope, err := encryptData("hunter2", []byte("alice 6 JBSWY3DPEHPK3PXP\n"))
+	if err != nil {
+		t.Fatalf("encryptData: %v", err)
+	}
+	if _, err := decryptData("wrong", envelope); err == nil {
+		t.Fatal("decryptData: got nil error for wrong passphrase, want one")
+	}
+}
+
+func TestDecryptTamperedCiphertext(t *testing.T) {
+	envelope, err := encryptData("hunter2", []byte("alice 6 JBSWY3DPEHPK3PXP\n"))
+	if err != nil {
+		t.Fatalf("encryptData: %v", err)
+	}
+	// Flip a character in the base64 ciphertext; the MAC (or, when the
+	// flipped character is no longer valid base64, the decoder) must catch
+	// this before the (also-authenticated) GCM open is even attempted.
+	b := []byte(envelope.CipherText)
+	b[0] ^= 1
+	envelope.CipherText = string(b)
+
+	if _, err := decryptData("hunter2", envelope); err == nil {
+		t.Fatal("decryptData: got nil error for tampered ciphertext, want one")
+	}
+}
+
+// TestComputeMACIndependentOfCipherKey locks in that the MAC key and the
+// AES key are independent halves of the derived key, not overlapping
+// bytes of the same material.
+func TestComputeMACIndependentOfCipherKey(t *testing.T) {
+	key := make([]byte, defaultScryptDKLen)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	ciphertext := []byte("ciphertext")
+	mac := computeMAC(key, ciphertext)
+
+	// Changing only the first half (the cipher key) must not change the MAC.
+	altered := make([]byte, len(key))
+	copy(altered, key)
+	altered[0] ^= 0xff
+	if got := computeMAC(altered, ciphertext); string(got) != string(mac) {
+		t.Fatal("computeMAC changed when only the cipher-key half changed; MAC key overlaps the cipher key")
+	}
+}
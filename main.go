@@ -33,7 +33,29 @@
 // the key and the current time, so it is important that the system clock have
 // at least one-minute accuracy.
 //
-// The keychain is stored unencrypted in the text file $HOME/.2fa.
+// The keychain is stored unencrypted in the text file $HOME/.2fa, unless
+// “2fa -encrypt” has been used to turn it into a passphrase-protected
+// keychain, in which case every command prompts for the passphrase before
+// reading or writing it.
+//
+// “2fa -encrypt” converts the keychain in place: it prompts twice for a new
+// passphrase and rewrites $HOME/.2fa as an encrypted JSON envelope. Existing
+// plaintext keychains keep working untouched until -encrypt is used.
+//
+// “2fa -add -backend keyring name” stores the raw secret in the operating
+// system's native credential store instead of $HOME/.2fa, keeping only the
+// name, digit count and HOTP counter in the keychain file. “2fa -migrate
+// keyring” moves every existing key over to the OS keyring in one shot.
+//
+// “2fa -export encrypted <file>” writes a portable, passphrase-protected
+// backup of the whole keychain; “2fa -import encrypted <file>” restores
+// one, merging it into the current keychain ("-overwrite" lets entries in
+// the archive replace same-named existing keys instead of being skipped).
+//
+// “2fa -add -qr image.png” decodes an otpauth:// QR code from an image file
+// and adds it to the keychain. “2fa -qr name” renders the equivalent
+// provisioning QR code for a stored key to standard error, for re-enrolling
+// a phone from a desktop key.
 //
 // # Example
 //
@@ -84,12 +106,18 @@ import (
 )
 
 var (
-	flagAdd    = flag.Bool("add", false, "add a key")
-	flagList   = flag.Bool("list", false, "list keys")
-	flagHotp   = flag.Bool("hotp", false, "add key as HOTP (counter-based) key")
-	flag7      = flag.Bool("7", false, "generate 7-digit code")
-	flag8      = flag.Bool("8", false, "generate 8-digit code")
-	flagImport = flag.Bool("import", false, "import keys from file")
+	flagAdd       = flag.Bool("add", false, "add a key")
+	flagList      = flag.Bool("list", false, "list keys")
+	flagHotp      = flag.Bool("hotp", false, "add key as HOTP (counter-based) key")
+	flag7         = flag.Bool("7", false, "generate 7-digit code")
+	flag8         = flag.Bool("8", false, "generate 8-digit code")
+	flagImport    = flag.Bool("import", false, "import keys from file")
+	flagExport    = flag.String("export", "", "export keys to file in the given format")
+	flagEncrypt   = flag.Bool("encrypt", false, "add passphrase encryption to the keychain")
+	flagBackend   = flag.String("backend", "", "secret storage backend for -add (\"\" or \"keyring\")")
+	flagMigrate   = flag.String("migrate", "", "migrate all keys to a different secret backend (\"keyring\")")
+	flagOverwrite = flag.Bool("overwrite", false, "overwrite existing keys when importing an encrypted archive")
+	flagQR        = flag.String("qr", "", "with -add, path to a QR code image to enroll from; alone, a stored key name to render as a QR code")
 )
 
 func usage() {
@@ -97,7 +125,15 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "\t2fa -add [-7] [-8] [-hotp] keyname\n")
 	fmt.Fprintf(os.Stderr, "\t2fa -list\n")
 	fmt.Fprintf(os.Stderr, "\t2fa keyname\n")
-	fmt.Fprintf(os.Stderr, "\t2fa -import 2fas <file>\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -import 2fas|otpauth|otpauth-migration|aegis|andotp <file>\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -export 2fas|otpauth|aegis|andotp <file>\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -export encrypted <file>\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -import [-overwrite] encrypted <file>\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -add -qr <image.png>\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -qr keyname\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -encrypt\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -add -backend keyring keyname\n")
+	fmt.Fprintf(os.Stderr, "\t2fa -migrate keyring\n")
 	os.Exit(2)
 }
 
@@ -109,6 +145,31 @@ func main() {
 
 	k := readKeychain(filepath.Join(os.Getenv("HOME"), ".2fa"))
 
+	if *flagEncrypt {
+		if flag.NArg() != 0 {
+			usage()
+		}
+		k.enableEncryption()
+		return
+	}
+	if *flagMigrate != "" {
+		if flag.NArg() != 0 || *flagMigrate != "keyring" {
+			usage()
+		}
+		k.migrateToKeyring()
+		return
+	}
+	if *flagQR != "" {
+		if flag.NArg() != 0 {
+			usage()
+		}
+		if *flagAdd {
+			k.addFromQR(*flagQR)
+		} else {
+			k.showQR(*flagQR)
+		}
+		return
+	}
 	if *flagList {
 		if flag.NArg() != 0 {
 			usage()
@@ -120,12 +181,23 @@ func main() {
 		if flag.NArg() != 2 {
 			usage()
 		}
-		format := flag.Arg(0)
-		file := flag.Arg(1)
-		if format != "2fas" {
-			log.Fatalf("unsupported import format: %s", format)
+		format, file := flag.Arg(0), flag.Arg(1)
+		if format == "encrypted" {
+			k.importEncryptedArchive(file, *flagOverwrite)
+			return
+		}
+		k.importFrom(format, file)
+		return
+	}
+	if *flagExport != "" {
+		if flag.NArg() != 1 {
+			usage()
+		}
+		if *flagExport == "encrypted" {
+			k.exportEncryptedArchive(flag.Arg(0))
+			return
 		}
-		k.import2fas(file)
+		k.exportTo(*flagExport, flag.Arg(0))
 		return
 	}
 	if flag.NArg() == 0 && !*flagAdd {
@@ -147,12 +219,18 @@ type Keychain struct {
 	file string
 	data []byte
 	keys map[string]Key
+
+	encrypted  bool   // keychain file is an encrypted JSON envelope
+	passphrase string // passphrase used to decrypt it, kept for re-encrypting on write
+
+	store SecretStore // lazily opened OS keyring, for keys with Key.backend set
 }
 
 type Key struct {
-	raw    []byte
-	digits int
-	offset int // offset of counter
+	raw     []byte
+	digits  int
+	offset  int  // offset of counter
+	backend bool // secret lives in the OS keyring, not in raw/the keychain file
 }
 
 const counterLen = 20
@@ -166,7 +244,7 @@ func (c *Keychain) fuzzyMatch(search string) []string {
 
 	// Convert search to lowercase for case-insensitive matching
 	search = strings.ToLower(search)
-	
+
 	// Create lowercase version of all names for matching
 	lowerNames := make([]string, len(allNames))
 	for i, name := range allNames {
@@ -206,13 +284,19 @@ func readKeychain(file string) *Keychain {
 		file: file,
 		keys: make(map[string]Key),
 	}
-	data, err := ioutil.ReadFile(file)
+	raw, err := ioutil.ReadFile(file)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return c
 		}
 		log.Fatal(err)
 	}
+
+	data := raw
+	if isEncryptedKeychain(raw) {
+		data, c.passphrase = loadEncrypted(raw)
+		c.encrypted = true
+	}
 	c.data = data
 
 	lines := bytes.SplitAfter(data, []byte("\n"))
@@ -244,9 +328,11 @@ func readKeychain(file string) *Keychain {
 			nameParts := f[0:digitPos]
 			name := string(bytes.Join(nameParts, []byte(" ")))
 			k.digits = int(f[digitPos][0] - '0')
-			raw, err := decodeKey(string(f[digitPos+1]))
-			if err == nil {
+			secretField := string(f[digitPos+1])
+			raw, err := decodeKey(secretField)
+			if err == nil || secretField == keyringSentinel {
 				k.raw = raw
+				k.backend = secretField == keyringSentinel
 				if len(f) == digitPos+2 {
 					c.keys[name] = k
 					continue
@@ -306,11 +392,14 @@ func (c *Keychain) add(name string) {
 	}
 	text = strings.Map(noSpace, text)
 	text += strings.Repeat("=", -len(text)&7) // pad to 8 bytes
-	if _, err := decodeKey(text); err != nil {
+	raw, err := decodeKey(text)
+	if err != nil {
 		log.Fatalf("invalid key: %v", err)
 	}
 
-	line := fmt.Sprintf("%s %d %s", name, size, text)
+	secretField := c.resolveSecretField(name, raw, text)
+
+	line := fmt.Sprintf("%s %d %s", name, size, secretField)
 	if *flagHotp {
 		line += " " + strings.Repeat("0", 20)
 	}
@@ -350,6 +439,17 @@ func (c *Keychain) rewriteSorted(newEntries []string) error {
 		return allLines[i] < allLines[j]
 	})
 
+	var body bytes.Buffer
+	for _, line := range allLines {
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	c.data = body.Bytes()
+
+	if c.encrypted {
+		return c.writeEncrypted()
+	}
+
 	// Write sorted entries to file
 	f, err := os.OpenFile(c.file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
@@ -358,10 +458,8 @@ func (c *Keychain) rewriteSorted(newEntries []string) error {
 	defer f.Close()
 	f.Chmod(0600)
 
-	for _, line := range allLines {
-		if _, err := f.WriteString(line + "\n"); err != nil {
-			return fmt.Errorf("writing keychain: %v", err)
-		}
+	if _, err := f.Write(c.data); err != nil {
+		return fmt.Errorf("writing keychain: %v", err)
 	}
 
 	return nil
@@ -372,6 +470,14 @@ func (c *Keychain) code(name string) string {
 	if !ok {
 		log.Fatalf("no such key %q", name)
 	}
+	if k.backend && k.raw == nil {
+		raw, err := c.secretStore().Get(name)
+		if err != nil {
+			log.Fatalf("reading %q from OS keyring: %v", name, err)
+		}
+		k.raw = raw
+		c.keys[name] = k
+	}
 	var code int
 	if k.offset != 0 {
 		n, err := strconv.ParseUint(string(c.data[k.offset:k.offset+counterLen]), 10, 64)
@@ -380,15 +486,19 @@ func (c *Keychain) code(name string) string {
 		}
 		n++
 		code = hotp(k.raw, n, k.digits)
-		f, err := os.OpenFile(c.file, os.O_RDWR, 0600)
-		if err != nil {
-			log.Fatalf("opening keychain: %v", err)
-		}
-		if _, err := f.WriteAt([]byte(fmt.Sprintf("%0*d", counterLen, n)), int64(k.offset)); err != nil {
-			log.Fatalf("updating keychain: %v", err)
-		}
-		if err := f.Close(); err != nil {
-			log.Fatalf("updating keychain: %v", err)
+		if c.encrypted {
+			c.updateCounter(name, n)
+		} else {
+			f, err := os.OpenFile(c.file, os.O_RDWR, 0600)
+			if err != nil {
+				log.Fatalf("opening keychain: %v", err)
+			}
+			if _, err := f.WriteAt([]byte(fmt.Sprintf("%0*d", counterLen, n)), int64(k.offset)); err != nil {
+				log.Fatalf("updating keychain: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				log.Fatalf("updating keychain: %v", err)
+			}
 		}
 	} else {
 		// Time-based key.
@@ -0,0 +1,118 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base32"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG decoding for -add -qr
+	_ "image/png"  // register PNG decoding for -add -qr
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jammutkarsh/2fa/internal/otpauth"
+	"github.com/jammutkarsh/2fa/internal/qr"
+)
+
+// addFromQR decodes the otpauth:// URI encoded in the QR code at path and
+// adds it to the keychain, using the URI's issuer:account as the name.
+func (c *Keychain) addFromQR(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("opening QR image: %v", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		log.Fatalf("decoding image: %v", err)
+	}
+	text, err := qr.Decode(img)
+	if err != nil {
+		log.Fatalf("decoding QR code: %v", err)
+	}
+	u, err := otpauth.Parse(text)
+	if err != nil {
+		log.Fatalf("parsing QR code: %v", err)
+	}
+
+	name := u.Name()
+	if name == "" {
+		log.Fatalf("QR code has no account name")
+	}
+	if _, exists := c.keys[name]; exists {
+		log.Fatalf("key %q already exists", name)
+	}
+	if u.Period != 0 && u.Period != 30 {
+		log.Fatalf("QR code uses a %ds period, but 2fa only supports the standard 30s step", u.Period)
+	}
+	if u.Algorithm != "" && u.Algorithm != "SHA1" {
+		log.Fatalf("QR code uses %s, but 2fa only supports SHA1", u.Algorithm)
+	}
+
+	secret := strings.Map(noSpace, u.Secret)
+	secret += strings.Repeat("=", -len(secret)&7) // pad to 8 bytes
+	raw, err := decodeKey(secret)
+	if err != nil {
+		log.Fatalf("invalid key in QR code: %v", err)
+	}
+
+	digits := u.Digits
+	if digits == 0 {
+		digits = 6
+	}
+
+	secretField := c.resolveSecretField(name, raw, secret)
+	line := fmt.Sprintf("%s %d %s", name, digits, secretField)
+	if u.Type == "hotp" {
+		line += " " + fmt.Sprintf("%0*d", counterLen, u.Counter)
+	}
+
+	c.keys[name] = Key{} // temporary entry for sorting
+	if err := c.rewriteSorted([]string{line}); err != nil {
+		log.Fatalf("adding key: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "added %s from QR code\n", name)
+}
+
+// showQR renders name's otpauth:// provisioning URI as a QR code on
+// stderr, so a phone's authenticator app can scan it to re-enroll.
+func (c *Keychain) showQR(name string) {
+	k, ok := c.keys[name]
+	if !ok {
+		log.Fatalf("no such key %q", name)
+	}
+	if k.backend && k.raw == nil {
+		raw, err := c.secretStore().Get(name)
+		if err != nil {
+			log.Fatalf("reading %q from OS keyring: %v", name, err)
+		}
+		k.raw = raw
+	}
+
+	u := otpauth.URI{
+		Type:    "totp",
+		Account: name,
+		Secret:  base32.StdEncoding.EncodeToString(k.raw),
+		Digits:  k.digits,
+	}
+	if k.offset != 0 {
+		u.Type = "hotp"
+		n, err := strconv.ParseUint(string(c.data[k.offset:k.offset+counterLen]), 10, 64)
+		if err != nil {
+			log.Fatalf("malformed key counter for %q", name)
+		}
+		u.Counter = n
+	}
+
+	art, err := qr.RenderASCII(u.String())
+	if err != nil {
+		log.Fatalf("rendering QR code: %v", err)
+	}
+	fmt.Fprint(os.Stderr, art)
+}
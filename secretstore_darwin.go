@@ -0,0 +1,82 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// macKeyringService is the Keychain Access "service" (kSecAttrService) under
+// which all 2fa secrets are stored, one generic-password item per key name
+// (kSecAttrAccount).
+const macKeyringService = "2fa"
+
+// macSecretStore shells out to /usr/bin/security, the same approach used by
+// most CLI tools that talk to the macOS Keychain without linking
+// Security.framework via cgo.
+type macSecretStore struct{}
+
+func newOSSecretStore() (SecretStore, error) {
+	return macSecretStore{}, nil
+}
+
+func (macSecretStore) Get(name string) ([]byte, error) {
+	out, err := exec.Command("/usr/bin/security", "find-generic-password",
+		"-s", macKeyringService, "-a", name, "-w").Output()
+	if err != nil {
+		return nil, fmt.Errorf("security find-generic-password %q: %v", name, err)
+	}
+	return []byte(strings.TrimRight(string(out), "\n")), nil
+}
+
+func (macSecretStore) Set(name string, raw []byte) error {
+	// -U updates the item in place if it already exists.
+	cmd := exec.Command("/usr/bin/security", "add-generic-password",
+		"-s", macKeyringService, "-a", name, "-w", string(raw), "-U")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("security add-generic-password %q: %v: %s", name, err, stderr.String())
+	}
+	return nil
+}
+
+func (macSecretStore) Delete(name string) error {
+	err := exec.Command("/usr/bin/security", "delete-generic-password",
+		"-s", macKeyringService, "-a", name).Run()
+	if err != nil {
+		return fmt.Errorf("security delete-generic-password %q: %v", name, err)
+	}
+	return nil
+}
+
+func (macSecretStore) List() ([]string, error) {
+	// security(1) has no "list items for service" subcommand; dump-keychain
+	// greps the default keychain's text dump instead.
+	out, err := exec.Command("/usr/bin/security", "dump-keychain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("security dump-keychain: %v", err)
+	}
+	var names []string
+	for _, block := range strings.Split(string(out), "keychain: ") {
+		if !strings.Contains(block, `"svce"<blob>="`+macKeyringService+`"`) {
+			continue
+		}
+		i := strings.Index(block, `"acct"<blob>="`)
+		if i < 0 {
+			continue
+		}
+		rest := block[i+len(`"acct"<blob>="`):]
+		if j := strings.IndexByte(rest, '"'); j >= 0 {
+			names = append(names, rest[:j])
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// linuxKeyringService groups all of 2fa's Secret Service items together so
+// they show up under one entry in Seahorse/GNOME Keyring and similar UIs.
+const linuxKeyringService = "2fa"
+
+type linuxSecretStore struct{}
+
+func newOSSecretStore() (SecretStore, error) {
+	return linuxSecretStore{}, nil
+}
+
+func (linuxSecretStore) Get(name string) ([]byte, error) {
+	secret, err := keyring.Get(linuxKeyringService, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q from Secret Service: %v", name, err)
+	}
+	return []byte(secret), nil
+}
+
+func (linuxSecretStore) Set(name string, raw []byte) error {
+	if err := keyring.Set(linuxKeyringService, name, string(raw)); err != nil {
+		return fmt.Errorf("writing %q to Secret Service: %v", name, err)
+	}
+	return nil
+}
+
+func (linuxSecretStore) Delete(name string) error {
+	if err := keyring.Delete(linuxKeyringService, name); err != nil {
+		return fmt.Errorf("deleting %q from Secret Service: %v", name, err)
+	}
+	return nil
+}
+
+func (linuxSecretStore) List() ([]string, error) {
+	return nil, fmt.Errorf("listing Secret Service items is not supported; keep the name index in the keychain file instead")
+}
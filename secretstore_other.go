@@ -0,0 +1,16 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !windows && !linux
+
+package main
+
+import "fmt"
+
+// newOSSecretStore has no native credential store implementation on this
+// platform; -backend keyring and -migrate keyring report an error instead
+// of silently falling back to the plaintext file.
+func newOSSecretStore() (SecretStore, error) {
+	return nil, fmt.Errorf("no OS keyring backend is available on this platform")
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSecretStore is an in-memory SecretStore for tests, standing in for
+// the OS keyring.
+type fakeSecretStore struct {
+	secrets map[string][]byte
+}
+
+func newFakeSecretStore() *fakeSecretStore {
+	return &fakeSecretStore{secrets: make(map[string][]byte)}
+}
+
+func (f *fakeSecretStore) Get(name string) ([]byte, error) {
+	raw, ok := f.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("no secret for %q", name)
+	}
+	return raw, nil
+}
+
+func (f *fakeSecretStore) Set(name string, raw []byte) error {
+	f.secrets[name] = raw
+	return nil
+}
+
+func (f *fakeSecretStore) Delete(name string) error {
+	delete(f.secrets, name)
+	return nil
+}
+
+func (f *fakeSecretStore) List() ([]string, error) {
+	var names []string
+	for name := range f.secrets {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func newTestKeychain(t *testing.T) *Keychain {
+	t.Helper()
+	return &Keychain{
+		file: filepath.Join(t.TempDir(), "keychain"),
+		keys: make(map[string]Key),
+	}
+}
+
+func TestResolveSecretFieldDefaultBackend(t *testing.T) {
+	c := newTestKeychain(t)
+	c.store = newFakeSecretStore()
+
+	got := c.resolveSecretField("alice", []byte{1, 2, 3}, "JBSWY3DPEHPK3PXP")
+	if got != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("resolveSecretField = %q, want the encoded secret unchanged", got)
+	}
+	if len(c.store.(*fakeSecretStore).secrets) != 0 {
+		t.Fatal("resolveSecretField touched the SecretStore with the default backend")
+	}
+}
+
+func TestResolveSecretFieldKeyringBackend(t *testing.T) {
+	*flagBackend = "keyring"
+	defer func() { *flagBackend = "" }()
+
+	c := newTestKeychain(t)
+	store := newFakeSecretStore()
+	c.store = store
+
+	raw := []byte{1, 2, 3}
+	got := c.resolveSecretField("alice", raw, "JBSWY3DPEHPK3PXP")
+	if got != keyringSentinel {
+		t.Fatalf("resolveSecretField = %q, want %q", got, keyringSentinel)
+	}
+	stored, err := store.Get("alice")
+	if err != nil || string(stored) != string(raw) {
+		t.Fatalf("store.Get(alice) = %v, %v; want %v, nil", stored, err, raw)
+	}
+}
+
+func TestMigrateToKeyring(t *testing.T) {
+	c := newTestKeychain(t)
+	c.store = newFakeSecretStore()
+	c.keys["alice"] = Key{raw: []byte{1, 2, 3}, digits: 6}
+
+	c.migrateToKeyring()
+
+	k := c.keys["alice"]
+	if !k.backend {
+		t.Fatal("migrateToKeyring did not mark the key as backend-stored")
+	}
+	stored, err := c.store.Get("alice")
+	if err != nil || string(stored) != string([]byte{1, 2, 3}) {
+		t.Fatalf("store.Get(alice) = %v, %v", stored, err)
+	}
+}
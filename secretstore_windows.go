@@ -0,0 +1,65 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/danieljoos/wincred"
+)
+
+// windowsTargetPrefix namespaces 2fa's entries in the Windows Credential
+// Manager so they don't collide with unrelated generic credentials.
+const windowsTargetPrefix = "2fa/"
+
+type windowsSecretStore struct{}
+
+func newOSSecretStore() (SecretStore, error) {
+	return windowsSecretStore{}, nil
+}
+
+func (windowsSecretStore) Get(name string) ([]byte, error) {
+	cred, err := wincred.GetGenericCredential(windowsTargetPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("reading credential for %q: %v", name, err)
+	}
+	return cred.CredentialBlob, nil
+}
+
+func (windowsSecretStore) Set(name string, raw []byte) error {
+	cred := wincred.NewGenericCredential(windowsTargetPrefix + name)
+	cred.CredentialBlob = raw
+	if err := cred.Write(); err != nil {
+		return fmt.Errorf("writing credential for %q: %v", name, err)
+	}
+	return nil
+}
+
+func (windowsSecretStore) Delete(name string) error {
+	cred, err := wincred.GetGenericCredential(windowsTargetPrefix + name)
+	if err != nil {
+		return fmt.Errorf("reading credential for %q: %v", name, err)
+	}
+	if err := cred.Delete(); err != nil {
+		return fmt.Errorf("deleting credential for %q: %v", name, err)
+	}
+	return nil
+}
+
+func (windowsSecretStore) List() ([]string, error) {
+	creds, err := wincred.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing credentials: %v", err)
+	}
+	var names []string
+	for _, cred := range creds {
+		if len(cred.TargetName) > len(windowsTargetPrefix) && cred.TargetName[:len(windowsTargetPrefix)] == windowsTargetPrefix {
+			names = append(names, cred.TargetName[len(windowsTargetPrefix):])
+		}
+	}
+	return names, nil
+}